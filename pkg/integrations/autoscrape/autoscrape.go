@@ -0,0 +1,127 @@
+// Package autoscrape lets integrations be scraped without the Prometheus
+// instance Manager or a synthetic instance.Config. A single Scraper owns a
+// Prometheus discovery manager and scrape.Manager pair and appends samples
+// straight into a shared storage.Appendable (normally the WAL / remote-write
+// stack used by the rest of the Agent).
+package autoscrape
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/prometheus/config"
+	"github.com/prometheus/prometheus/discovery"
+	"github.com/prometheus/prometheus/scrape"
+	"github.com/prometheus/prometheus/storage"
+)
+
+// Target is a single scrape target for an integration that is being scraped
+// directly by a Scraper, bypassing the instance Manager.
+type Target struct {
+	// Labels to attach to the target, such as __address__ and any extra
+	// discovery labels the integration wants to expose.
+	Labels map[string]string
+}
+
+// Scraper runs its own discovery and scrape loop for a set of named
+// integrations and appends the results directly to an Appendable. It is the
+// autoscrape equivalent of what the instance Manager does for
+// YAML-configured scrape jobs.
+type Scraper struct {
+	log log.Logger
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	discovery *discovery.Manager
+	scrape    *scrape.Manager
+
+	mut     sync.Mutex
+	configs map[string]*config.ScrapeConfig
+	targets map[string]discovery.Configs
+}
+
+// NewScraper creates a new Scraper. Samples collected by integrations
+// registered with the Scraper are appended to app.
+func NewScraper(l log.Logger, app storage.Appendable) *Scraper {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	s := &Scraper{
+		log: l,
+
+		ctx:    ctx,
+		cancel: cancel,
+
+		discovery: discovery.NewManager(ctx, log.With(l, "component", "autoscrape discovery")),
+		scrape:    scrape.NewManager(&scrape.Options{}, log.With(l, "component", "autoscrape scrape manager"), app),
+
+		configs: make(map[string]*config.ScrapeConfig),
+		targets: make(map[string]discovery.Configs),
+	}
+
+	go s.run()
+	return s
+}
+
+func (s *Scraper) run() {
+	go func() {
+		if err := s.discovery.Run(); err != nil && err != context.Canceled {
+			level.Error(s.log).Log("msg", "autoscrape discovery manager exited unexpectedly", "err", err)
+		}
+	}()
+
+	if err := s.scrape.Run(s.discovery.SyncCh()); err != nil {
+		level.Error(s.log).Log("msg", "autoscrape scrape manager exited unexpectedly", "err", err)
+	}
+}
+
+// Set registers or updates the scrape config and discovery targets used to
+// scrape the integration identified by name. Calling Set again for the same
+// name replaces what was previously registered.
+func (s *Scraper) Set(name string, sc *config.ScrapeConfig, targets discovery.Configs) error {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	s.configs[name] = sc
+	s.targets[name] = targets
+	return s.sync()
+}
+
+// Delete removes a previously registered integration from the Scraper. It is
+// safe to call Delete for a name that was never registered.
+func (s *Scraper) Delete(name string) error {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	delete(s.configs, name)
+	delete(s.targets, name)
+	return s.sync()
+}
+
+// sync must be called with mut held.
+func (s *Scraper) sync() error {
+	scrapeConfigs := make([]*config.ScrapeConfig, 0, len(s.configs))
+	discoveryConfigs := make(map[string]discovery.Configs, len(s.targets))
+	for name, sc := range s.configs {
+		scrapeConfigs = append(scrapeConfigs, sc)
+		discoveryConfigs[sc.JobName] = s.targets[name]
+	}
+
+	if err := s.discovery.ApplyConfig(discoveryConfigs); err != nil {
+		return fmt.Errorf("failed to apply autoscrape discovery configs: %w", err)
+	}
+	if err := s.scrape.ApplyConfig(&config.Config{ScrapeConfigs: scrapeConfigs}); err != nil {
+		return fmt.Errorf("failed to apply autoscrape scrape configs: %w", err)
+	}
+	return nil
+}
+
+// Stop stops the Scraper and its discovery and scrape loops. It must not be
+// used again afterwards.
+func (s *Scraper) Stop() {
+	s.cancel()
+	s.scrape.Stop()
+}
@@ -0,0 +1,53 @@
+// Package common holds configuration shared by every integration,
+// regardless of integration type.
+package common
+
+import (
+	"time"
+
+	"github.com/prometheus/prometheus/pkg/relabel"
+)
+
+// DefaultConfig holds the default settings for Config.
+var DefaultConfig = Config{
+	Enabled:        true,
+	ScrapeInterval: 60 * time.Second,
+	ScrapeTimeout:  10 * time.Second,
+}
+
+// Config holds fields common to every integration's configuration. It is
+// meant to be embedded by each integration's own Config struct.
+type Config struct {
+	// Enabled turns the integration on or off.
+	Enabled bool `yaml:"enabled,omitempty"`
+
+	// Instance optionally identifies this integration instance among other
+	// instances of the same integration type, letting more than one run
+	// concurrently (e.g. two mysqld_exporter integrations against different
+	// DSNs). When unset, Manager uses the fixed identifier "default"; it is
+	// not derived from the integration's config, so every instance of a type
+	// beyond the first must set Instance.
+	Instance string `yaml:"instance,omitempty"`
+
+	// ScrapeIntegration overrides ManagerConfig.ScrapeIntegrations for this
+	// integration alone, when set.
+	ScrapeIntegration *bool `yaml:"scrape_integration,omitempty"`
+
+	ScrapeInterval time.Duration `yaml:"scrape_interval,omitempty"`
+	ScrapeTimeout  time.Duration `yaml:"scrape_timeout,omitempty"`
+
+	// WALTruncateFrequency overrides the instance-wide WAL truncation
+	// frequency for this integration's generated instance.Config, when set.
+	WALTruncateFrequency time.Duration `yaml:"wal_truncate_frequency,omitempty"`
+
+	RelabelConfigs       []*relabel.Config `yaml:"relabel_configs,omitempty"`
+	MetricRelabelConfigs []*relabel.Config `yaml:"metric_relabel_configs,omitempty"`
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler for Config.
+func (c *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = DefaultConfig
+
+	type plain Config
+	return unmarshal((*plain)(c))
+}
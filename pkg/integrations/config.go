@@ -0,0 +1,165 @@
+package integrations
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/go-kit/kit/log"
+	"github.com/grafana/agent/pkg/integrations/common"
+	"gopkg.in/yaml.v2"
+)
+
+// Config describes how to instantiate and scrape a particular integration.
+// Each integration type implements its own Config and registers it with
+// RegisterIntegration so it can appear in the `integrations` block of the
+// Agent's YAML config.
+type Config interface {
+	// Name returns the (type) name of the integration, e.g. "redis_exporter".
+	// It is shared by every instance of that integration type.
+	Name() string
+
+	// CommonConfig returns the settings common to every integration.
+	CommonConfig() common.Config
+
+	// NewIntegration returns a new, ready-to-run Integration from this
+	// Config.
+	NewIntegration(l log.Logger) (Integration, error)
+}
+
+// IntegrationScrapeConfig describes a single metrics endpoint exposed by an
+// Integration. Manager generates one Prometheus scrape job per entry; an
+// Autoscrapable Integration must have exactly one entry, or it is not
+// scraped.
+type IntegrationScrapeConfig struct {
+	// JobName distinguishes this endpoint from any others exposed by the
+	// same Integration.
+	JobName string
+	// MetricsPath is the HTTP path MetricsHandler serves this endpoint's
+	// metrics on, relative to /integrations/<name>/<instance>.
+	MetricsPath string
+}
+
+// Integration is a running integration.
+type Integration interface {
+	// MetricsHandler returns the HTTP handler that serves this integration's
+	// metrics for scraping.
+	MetricsHandler() (http.Handler, error)
+
+	// ScrapeConfigs describes the metrics endpoints this integration exposes.
+	ScrapeConfigs() []IntegrationScrapeConfig
+
+	// Run starts the integration and blocks until ctx is canceled or a fatal
+	// error occurs.
+	Run(ctx context.Context) error
+}
+
+// Configs is a list of integration Configs, as read from YAML.
+type Configs []Config
+
+// registeredConfigs holds the set of integration types that may appear in
+// the `integrations` block, keyed by their registered (YAML) name.
+var registeredConfigs = map[string]func() Config{}
+
+// RegisterIntegration registers an integration type so it can be configured
+// under its name in the `integrations` block. newConfig must return a new
+// zero-value Config for the integration; integration packages call this from
+// their init function.
+func RegisterIntegration(name string, newConfig func() Config) {
+	registeredConfigs[name] = newConfig
+}
+
+// UnmarshalYAML unmarshals the integrations portion of a ManagerConfig. Every
+// key in the YAML mapping that matches a name passed to RegisterIntegration
+// is treated as that integration type; its value may be either a single
+// mapping (one instance of that integration) or a sequence of mappings
+// (multiple instances).
+func UnmarshalYAML(c *ManagerConfig, unmarshal func(interface{}) error) error {
+	type plain ManagerConfig
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+
+	var raw yaml.MapSlice
+	if err := unmarshal(&raw); err != nil {
+		return err
+	}
+
+	var configs Configs
+	for _, item := range raw {
+		name, ok := item.Key.(string)
+		if !ok {
+			continue
+		}
+		newConfig, ok := registeredConfigs[name]
+		if !ok {
+			// Not an integration key; already handled by the plain unmarshal
+			// above.
+			continue
+		}
+
+		if list, ok := item.Value.([]interface{}); ok {
+			for _, elem := range list {
+				ic := newConfig()
+				if err := remarshalYAML(elem, ic); err != nil {
+					return fmt.Errorf("failed to unmarshal %s config: %w", name, err)
+				}
+				configs = append(configs, ic)
+			}
+			continue
+		}
+
+		ic := newConfig()
+		if err := remarshalYAML(item.Value, ic); err != nil {
+			return fmt.Errorf("failed to unmarshal %s config: %w", name, err)
+		}
+		configs = append(configs, ic)
+	}
+
+	c.Integrations = configs
+	return nil
+}
+
+// MarshalYAML marshals a ManagerConfig, writing its Integrations back out
+// under their registered type names: a single instance marshals as a
+// mapping, multiple instances of the same type as a sequence.
+func MarshalYAML(c ManagerConfig) (interface{}, error) {
+	type plain ManagerConfig
+
+	var base yaml.MapSlice
+	if err := remarshalYAML((plain)(c), &base); err != nil {
+		return nil, err
+	}
+
+	byType := make(map[string][]Config)
+	var order []string
+	for _, ic := range c.Integrations {
+		if _, seen := byType[ic.Name()]; !seen {
+			order = append(order, ic.Name())
+		}
+		byType[ic.Name()] = append(byType[ic.Name()], ic)
+	}
+
+	for _, name := range order {
+		ics := byType[name]
+		if len(ics) == 1 {
+			base = append(base, yaml.MapItem{Key: name, Value: ics[0]})
+		} else {
+			base = append(base, yaml.MapItem{Key: name, Value: ics})
+		}
+	}
+
+	return base, nil
+}
+
+// remarshalYAML converts in (typically a generic interface{} produced by
+// unmarshaling into a yaml.MapSlice) into out by round-tripping it through
+// YAML. It's used to decode a single integration's settings into its
+// concrete Config type.
+func remarshalYAML(in, out interface{}) error {
+	bb, err := yaml.Marshal(in)
+	if err != nil {
+		return err
+	}
+	return yaml.Unmarshal(bb, out)
+}
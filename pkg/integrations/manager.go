@@ -2,9 +2,12 @@ package integrations
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"math/rand"
 	"net/http"
 	"path"
+	"strings"
 	"sync"
 	"time"
 
@@ -13,6 +16,7 @@ import (
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
 	"github.com/gorilla/mux"
+	"github.com/grafana/agent/pkg/integrations/autoscrape"
 	"github.com/grafana/agent/pkg/prom"
 	"github.com/grafana/agent/pkg/prom/instance"
 	"github.com/grafana/agent/pkg/prom/instance/configstore"
@@ -22,22 +26,120 @@ import (
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/config"
 	"github.com/prometheus/prometheus/discovery"
+	"github.com/prometheus/prometheus/discovery/targetgroup"
 	"github.com/prometheus/prometheus/pkg/relabel"
+	"github.com/prometheus/prometheus/storage"
 )
 
+// Autoscrapable is implemented by integrations whose metrics should be
+// scraped directly by the Manager's autoscrape.Scraper instead of being
+// wired through a synthetic instance.Config. Integrations that implement
+// this interface skip instanceConfigForIntegration and scrapeServiceDiscovery
+// entirely. ApplyDefaults still requires a configured WAL directory unless
+// the integration's Config also implements AutoscrapeConfig.
+type Autoscrapable interface {
+	Integration
+
+	// Targets returns the set of scrape targets that should be used to scrape
+	// this integration's MetricsHandler.
+	Targets() []autoscrape.Target
+}
+
+// AutoscrapeConfig is optionally implemented by a Config whose
+// NewIntegration is known to always produce an Autoscrapable Integration.
+// ApplyDefaults uses it to skip the wal_directory requirement for
+// autoscraped integrations without having to construct the integration,
+// which ApplyDefaults runs before NewIntegration is ever called.
+type AutoscrapeConfig interface {
+	Config
+
+	// Autoscrape reports whether this integration is scraped directly by the
+	// Manager's autoscrape.Scraper rather than through the WAL-backed
+	// instance Manager.
+	Autoscrape() bool
+}
+
+// Starter is optionally implemented by an Integration that needs to perform
+// one-time setup before Run is called in a loop.
+type Starter interface {
+	Start(ctx context.Context) error
+}
+
+// Stopper is optionally implemented by an Integration that needs to perform
+// cleanup once it has stopped running for good.
+type Stopper interface {
+	Stop() error
+}
+
+// HealthChecker is optionally implemented by an Integration that can report
+// richer health information than its lifecycle state alone, e.g. by
+// checking connectivity to whatever it's exporting metrics for.
+type HealthChecker interface {
+	Health(ctx context.Context) error
+}
+
+// ServiceDiscoverer is optionally implemented by an Integration that wants to
+// supply its own scrape targets (a Consul, Kubernetes, or file_sd config, for
+// example) instead of being scraped solely at the agent's own listen
+// address. Returning nil falls back to that default behavior.
+type ServiceDiscoverer interface {
+	DiscoveryConfigs() discovery.Configs
+}
+
+// integrationState describes the lifecycle state of a running integration.
+type integrationState string
+
+const (
+	stateStarting   integrationState = "starting"
+	stateRunning    integrationState = "running"
+	stateBackingOff integrationState = "backing-off"
+	stateFailed     integrationState = "failed"
+	stateStopped    integrationState = "stopped"
+)
+
+// integrationHealth is the health of a single integration, returned by the
+// per-integration and aggregate health endpoints.
+type integrationHealth struct {
+	State     integrationState `json:"state"`
+	LastError string           `json:"lastError,omitempty"`
+}
+
+// integrationReplaceTimeout bounds how long ApplyConfig will wait, while
+// holding m.cfgMut and m.integrationsMut, for an outgoing integration to
+// exit before starting its replacement.
+const integrationReplaceTimeout = 30 * time.Second
+
 var (
 	integrationAbnormalExits = promauto.NewCounterVec(prometheus.CounterOpts{
 		Name: "agent_prometheus_integration_abnormal_exits_total",
 		Help: "Total number of times an agent integration exited unexpectedly, causing it to be restarted.",
 	}, []string{"integration_name"})
+
+	integrationUp = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "agent_integration_up",
+		Help: "1 if the integration is running and considered healthy, 0 otherwise.",
+	}, []string{"integration"})
+
+	integrationRestartBackoffSeconds = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "agent_integration_restart_backoff_seconds",
+		Help: "Current restart backoff duration for an integration that exited abnormally.",
+	}, []string{"integration_name"})
+
+	integrationConsecutiveFailures = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "agent_integration_consecutive_failures",
+		Help: "Number of consecutive abnormal exits for an integration since its last stable run.",
+	}, []string{"integration_name"})
 )
 
 // DefaultManagerConfig holds the default settings for integrations.
 var DefaultManagerConfig = ManagerConfig{
-	ScrapeIntegrations:        true,
-	IntegrationRestartBackoff: 5 * time.Second,
-	UseHostnameLabel:          true,
-	ReplaceInstanceLabel:      true,
+	ScrapeIntegrations:                   true,
+	IntegrationRestartBackoff:            5 * time.Second,
+	IntegrationRestartMaxBackoff:         2 * time.Minute,
+	IntegrationRestartBackoffResetWindow: time.Minute,
+	CircuitBreakerCooldown:               10 * time.Minute,
+	UseHostnameLabel:                     true,
+	ReplaceInstanceLabel:                 true,
 }
 
 // ManagerConfig holds the configuration for all integrations.
@@ -61,8 +163,30 @@ type ManagerConfig struct {
 	// Prometheus RW configs to use for all integrations.
 	PrometheusRemoteWrite []*instance.RemoteWriteConfig `yaml:"prometheus_remote_write,omitempty"`
 
+	// IntegrationRestartBackoff is the base backoff duration used when an
+	// integration exits abnormally. Successive restarts within the same
+	// failure streak back off exponentially, with full jitter, up to
+	// IntegrationRestartMaxBackoff.
 	IntegrationRestartBackoff time.Duration `yaml:"integration_restart_backoff,omitempty"`
 
+	// IntegrationRestartMaxBackoff caps the exponential backoff applied to
+	// integration restarts.
+	IntegrationRestartMaxBackoff time.Duration `yaml:"integration_restart_max_backoff,omitempty"`
+
+	// IntegrationRestartBackoffResetWindow is how long an integration must run
+	// without exiting abnormally before its consecutive failure count (and
+	// therefore its backoff) resets back to the base.
+	IntegrationRestartBackoffResetWindow time.Duration `yaml:"integration_restart_backoff_reset_window,omitempty"`
+
+	// MaxConsecutiveFailures trips the circuit breaker for an integration once
+	// it has exited abnormally this many times in a row. 0 disables the
+	// circuit breaker, relying on backoff alone.
+	MaxConsecutiveFailures int `yaml:"max_consecutive_failures,omitempty"`
+
+	// CircuitBreakerCooldown is how long to wait before retrying an
+	// integration whose circuit breaker has tripped.
+	CircuitBreakerCooldown time.Duration `yaml:"circuit_breaker_cooldown,omitempty"`
+
 	// ListenPort tells the integration Manager which port the Agent is
 	// listening on for generating Prometheus instance configs.
 	ListenPort int `yaml:"-"`
@@ -113,20 +237,30 @@ func (c *ManagerConfig) DefaultRelabelConfigs(hostname string) []*relabel.Config
 // that it can be used.
 //
 // If any integrations are enabled and are configured to be scraped, the
-// Prometheus configuration must have a WAL directory configured.
+// Prometheus configuration must have a WAL directory configured, unless the
+// integration is autoscraped: autoscraped integrations write samples
+// directly to the Agent's own Appendable rather than through a synthetic
+// instance.Config, so they never touch the WAL-backed instance Manager.
 func (c *ManagerConfig) ApplyDefaults(cfg *prom.Config) error {
 	for _, ic := range c.Integrations {
-		if !ic.CommonConfig().Enabled {
+		common := ic.CommonConfig()
+		if !common.Enabled {
 			continue
 		}
 
 		scrapeIntegration := c.ScrapeIntegrations
-		if common := ic.CommonConfig(); common.ScrapeIntegration != nil {
+		if common.ScrapeIntegration != nil {
 			scrapeIntegration = *common.ScrapeIntegration
 		}
+		if !scrapeIntegration {
+			continue
+		}
+
+		if ac, ok := ic.(AutoscrapeConfig); ok && ac.Autoscrape() {
+			continue
+		}
 
-		// WAL must be configured if an integration is going to be scraped.
-		if scrapeIntegration && cfg.WALDir == "" {
+		if cfg.WALDir == "" {
 			return fmt.Errorf("no wal_directory configured")
 		}
 	}
@@ -150,14 +284,20 @@ type Manager struct {
 	im        instance.Manager
 	validator configstore.Validator
 
+	// autoscraper is used to scrape integrations that implement Autoscrapable,
+	// bypassing im entirely.
+	autoscraper *autoscrape.Scraper
+
 	integrationsMut sync.RWMutex
 	integrations    map[string]*integrationProcess
 }
 
 // NewManager creates a new integrations manager. NewManager must be given an
 // InstanceManager which is responsible for accepting instance configs to
-// scrape and send metrics from running integrations.
-func NewManager(c ManagerConfig, logger log.Logger, im instance.Manager, validate configstore.Validator) (*Manager, error) {
+// scrape and send metrics from running integrations. autoscrapeApp is the
+// Appendable that integrations implementing Autoscrapable will have their
+// samples written to, bypassing im.
+func NewManager(c ManagerConfig, logger log.Logger, im instance.Manager, validate configstore.Validator, autoscrapeApp storage.Appendable) (*Manager, error) {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	m := &Manager{
@@ -166,8 +306,9 @@ func NewManager(c ManagerConfig, logger log.Logger, im instance.Manager, validat
 		ctx:    ctx,
 		cancel: cancel,
 
-		im:        im,
-		validator: validate,
+		im:          im,
+		validator:   validate,
+		autoscraper: autoscrape.NewScraper(log.With(logger, "subsystem", "autoscrape"), autoscrapeApp),
 
 		integrations: make(map[string]*integrationProcess, len(c.Integrations)),
 	}
@@ -205,22 +346,89 @@ func (m *Manager) ApplyConfig(cfg ManagerConfig) error {
 		// No-op
 	}
 
-	// Iterate over our integrations. New or changed integrations will be
-	// started, with their existing counterparts being shut down.
+	// Reject configs that would produce duplicate instance IDs for the same
+	// integration name before we start tearing anything down.
+	seenKeys := make(map[string]bool, len(cfg.Integrations))
+	for _, ic := range cfg.Integrations {
+		instanceID, err := instanceIDForConfig(ic)
+		if err != nil {
+			return fmt.Errorf("failed to compute instance ID for %s: %w", ic.Name(), err)
+		}
+		key := integrationKey(ic.Name(), instanceID)
+		if seenKeys[key] {
+			return fmt.Errorf("duplicate instance %q configured for integration %s", instanceID, ic.Name())
+		}
+		seenKeys[key] = true
+	}
+
+	// Find integrations that have changed and need to be recreated, and start
+	// stopping all of them up front so we can wait for them to exit
+	// concurrently below rather than one at a time.
+	type pendingReplacement struct {
+		key string
+		old *integrationProcess
+	}
+	var pending []pendingReplacement
+	for _, ic := range cfg.Integrations {
+		instanceID, err := instanceIDForConfig(ic)
+		if err != nil {
+			// Already validated above; this should not happen.
+			return err
+		}
+		key := integrationKey(ic.Name(), instanceID)
+
+		p, exist := m.integrations[key]
+		if !exist || util.CompareYAML(p.cfg, ic) {
+			continue
+		}
+		p.stop()
+		pending = append(pending, pendingReplacement{key: key, old: p})
+	}
+
+	// Wait for every outgoing integration to exit before starting its
+	// replacement, so both copies never briefly run at once and race over
+	// shared resources like a listening port. This wait is bounded by a
+	// single deadline shared across all of them: ApplyConfig holds m.cfgMut
+	// and m.integrationsMut for its whole duration, and those same locks gate
+	// every other integration's /metrics and /health routes (see WireAPI), so
+	// replacing N hung integrations must not block for N times as long as
+	// replacing just one.
+	if len(pending) > 0 {
+		waitCtx, cancel := context.WithTimeout(context.Background(), integrationReplaceTimeout)
+		var wg sync.WaitGroup
+		for _, r := range pending {
+			wg.Add(1)
+			go func(r pendingReplacement) {
+				defer wg.Done()
+				select {
+				case <-r.old.exited:
+				case <-waitCtx.Done():
+					level.Error(m.logger).Log("msg", "integration did not exit in time while being replaced; starting its replacement anyway", "integration", r.old.cfg.Name(), "timeout", integrationReplaceTimeout)
+				}
+			}(r)
+		}
+		wg.Wait()
+		cancel()
+	}
+	for _, r := range pending {
+		delete(m.integrations, r.key)
+	}
+
+	// Iterate over our integrations. New integrations, and changed ones whose
+	// outgoing process was stopped and removed above, will be started;
+	// unchanged ones are skipped.
 	for _, ic := range cfg.Integrations {
 		// Key is used to identify the instance of this integration within the
 		// instance manager and within our set of running integrations.
-		key := integrationKey(ic.Name())
-
-		// Look for an existing integration with the same key. If it exists and
-		// is unchanged, we have nothing to do. Otherwise, we're going to recreate
-		// it with the new settings, so we'll need to stop it.
-		if p, exist := m.integrations[key]; exist {
-			if util.CompareYAML(p.cfg, ic) {
-				continue
-			}
-			p.stop()
-			delete(m.integrations, key)
+		instanceID, err := instanceIDForConfig(ic)
+		if err != nil {
+			// Already validated above; this should not happen.
+			return err
+		}
+		key := integrationKey(ic.Name(), instanceID)
+
+		if p, exist := m.integrations[key]; exist && util.CompareYAML(p.cfg, ic) {
+			continue
 		}
 
 		l := log.With(m.logger, "integration", ic.Name())
@@ -245,8 +453,16 @@ func (m *Manager) ApplyConfig(cfg ManagerConfig) error {
 			ctx:  ctx,
 			stop: cancel,
 
-			wg:   &m.wg,
-			wait: m.instanceBackoff,
+			exited: make(chan struct{}),
+
+			wg: &m.wg,
+			backoff: backoffConfig{
+				base:                   cfg.IntegrationRestartBackoff,
+				max:                    cfg.IntegrationRestartMaxBackoff,
+				resetWindow:            cfg.IntegrationRestartBackoffResetWindow,
+				maxConsecutiveFailures: cfg.MaxConsecutiveFailures,
+				circuitBreakerCooldown: cfg.CircuitBreakerCooldown,
+			},
 		}
 		go p.Run()
 		m.integrations[key] = p
@@ -257,7 +473,8 @@ func (m *Manager) ApplyConfig(cfg ManagerConfig) error {
 	for key, process := range m.integrations {
 		foundConfig := false
 		for _, ic := range cfg.Integrations {
-			if integrationKey(ic.Name()) == key {
+			instanceID, err := instanceIDForConfig(ic)
+			if err == nil && integrationKey(ic.Name(), instanceID) == key {
 				foundConfig = true
 				break
 			}
@@ -267,21 +484,36 @@ func (m *Manager) ApplyConfig(cfg ManagerConfig) error {
 		}
 
 		_ = m.im.DeleteConfig(key)
+		_ = m.autoscraper.Delete(key)
 		process.stop()
 		delete(m.integrations, key)
 	}
 
-	// Re-apply configs to our instance manager for all running integrations.
-	// Generated scrape configs may change in between calls to ApplyConfig even
-	// if the configs for the integration didn't.
+	// Re-apply configs to our instance manager (or autoscraper) for all running
+	// integrations. Generated scrape configs may change in between calls to
+	// ApplyConfig even if the configs for the integration didn't.
 	for key, p := range m.integrations {
 		shouldCollect := cfg.ScrapeIntegrations
 		if common := p.cfg.CommonConfig(); common.ScrapeIntegration != nil {
 			shouldCollect = *common.ScrapeIntegration
 		}
 
-		switch shouldCollect {
-		case true:
+		autoscrapable, isAutoscrapable := p.i.(Autoscrapable)
+
+		switch {
+		case shouldCollect && isAutoscrapable:
+			scrapeConfigs := m.scrapeConfigsForIntegration(p.cfg, p.i, cfg, true)
+			targets := autoscrapeTargetsToDiscoveryConfigs(autoscrapable.Targets())
+			if len(scrapeConfigs) != 1 {
+				level.Error(p.log).Log("msg", "autoscraped integrations must have exactly one scrape config", "integration", p.cfg.Name())
+				failed = true
+				break
+			}
+			if err := m.autoscraper.Set(key, scrapeConfigs[0], targets); err != nil {
+				level.Error(p.log).Log("msg", "failed to apply autoscrape config for integration. integration will not be scraped", "err", err, "integration", p.cfg.Name())
+				failed = true
+			}
+		case shouldCollect && !isAutoscrapable:
 			instanceConfig := m.instanceConfigForIntegration(p.cfg, p.i, cfg)
 			if err := m.validator(&instanceConfig); err != nil {
 				level.Error(p.log).Log("msg", "failed to validate generated scrape config for integration. integration will not be scraped", "err", err, "integration", p.cfg.Name())
@@ -293,11 +525,12 @@ func (m *Manager) ApplyConfig(cfg ManagerConfig) error {
 				level.Error(p.log).Log("msg", "failed to apply integration. integration will not be scraped", "err", err, "integration", p.cfg.Name())
 				failed = true
 			}
-		case false:
+		case !shouldCollect:
 			// If a previous instance of the config was being scraped, we need to
-			// delete it here. Calling DeleteConfig when nothing is running is a safe
-			// operation.
+			// delete it here. Calling DeleteConfig/autoscraper.Delete when nothing
+			// is running is a safe operation.
 			_ = m.im.DeleteConfig(key)
+			_ = m.autoscraper.Delete(key)
 		}
 	}
 
@@ -309,6 +542,17 @@ func (m *Manager) ApplyConfig(cfg ManagerConfig) error {
 	return nil
 }
 
+// backoffConfig holds the restart backoff and circuit-breaker tunables for an
+// integrationProcess, snapshotted from ManagerConfig when the process is
+// created.
+type backoffConfig struct {
+	base                   time.Duration
+	max                    time.Duration
+	resetWindow            time.Duration
+	maxConsecutiveFailures int
+	circuitBreakerCooldown time.Duration
+}
+
 // integrationProcess is a running integration.
 type integrationProcess struct {
 	log  log.Logger
@@ -317,44 +561,231 @@ type integrationProcess struct {
 	cfg  Config
 	i    Integration
 
-	wg   *sync.WaitGroup
-	wait func(cfg Config, err error)
+	wg      *sync.WaitGroup
+	backoff backoffConfig
+
+	// exited is closed once Run has returned for good, letting callers wait
+	// for the integration to actually terminate rather than just requesting
+	// that it do so.
+	exited chan struct{}
+
+	stateMut            sync.RWMutex
+	state               integrationState
+	lastErr             error
+	consecutiveFailures int
 }
 
 // Run runs the integration until the process is canceled.
 func (p *integrationProcess) Run() {
+	defer close(p.exited)
 	defer func() {
 		if r := recover(); r != nil {
 			err := fmt.Errorf("%v", r)
 			level.Error(p.log).Log("msg", "integration has panicked. THIS IS A BUG!", "err", err, "integration", p.cfg.Name())
+			p.setState(stateFailed, err)
 		}
 	}()
 
 	p.wg.Add(1)
 	defer p.wg.Done()
 
+	p.setState(stateStarting, nil)
+	if starter, ok := p.i.(Starter); ok {
+		if err := starter.Start(p.ctx); err != nil {
+			level.Error(p.log).Log("msg", "integration failed to start", "integration", p.cfg.Name(), "err", err)
+			p.setState(stateFailed, err)
+			return
+		}
+	}
+
 	for {
+		p.setState(stateRunning, nil)
+		runStart := time.Now()
 		err := p.i.Run(p.ctx)
 		if err != nil && err != context.Canceled {
-			p.wait(p.cfg, err)
-		} else {
-			level.Info(p.log).Log("msg", "stopped integration", "integration", p.cfg.Name())
-			break
+			if circuitOpen := p.backoffAndWait(runStart, err); circuitOpen {
+				break
+			}
+			continue
+		}
+		level.Info(p.log).Log("msg", "stopped integration", "integration", p.cfg.Name())
+		break
+	}
+
+	if stopper, ok := p.i.(Stopper); ok {
+		if err := stopper.Stop(); err != nil {
+			level.Error(p.log).Log("msg", "integration failed to stop cleanly", "integration", p.cfg.Name(), "err", err)
 		}
 	}
+	p.setState(stateStopped, nil)
 }
 
-func (m *Manager) instanceBackoff(cfg Config, err error) {
-	m.cfgMut.RLock()
-	defer m.cfgMut.RUnlock()
+// setState records the current lifecycle state of the integration, along
+// with the last error it hit (if any), and updates agent_integration_up.
+func (p *integrationProcess) setState(s integrationState, err error) {
+	p.stateMut.Lock()
+	defer p.stateMut.Unlock()
 
-	integrationAbnormalExits.WithLabelValues(cfg.Name()).Inc()
-	level.Error(m.logger).Log("msg", "integration stopped abnormally, restarting after backoff", "err", err, "integration", cfg.Name(), "backoff", m.cfg.IntegrationRestartBackoff)
-	time.Sleep(m.cfg.IntegrationRestartBackoff)
+	p.state = s
+	p.lastErr = err
+
+	up := 0.0
+	if s == stateRunning {
+		up = 1.0
+	}
+	integrationUp.WithLabelValues(p.cfg.Name()).Set(up)
+}
+
+// Health returns the current health of the integration. The recorded
+// lifecycle state is reported whenever the integration is not running; a
+// running integration that implements HealthChecker is then consulted too.
+func (p *integrationProcess) Health(ctx context.Context) integrationHealth {
+	p.stateMut.RLock()
+	state, lastErr := p.state, p.lastErr
+	p.stateMut.RUnlock()
+
+	// The tracked lifecycle state takes precedence: if the process isn't
+	// actually running (it's backing off, or it's permanently stopped/failed,
+	// e.g. after its circuit breaker gave up), report that directly. A
+	// HealthChecker that only probes the external system it monitors has no
+	// way to know the integration's own Run loop has already exited for good.
+	if state != stateRunning {
+		h := integrationHealth{State: state}
+		if lastErr != nil {
+			h.LastError = lastErr.Error()
+		}
+		return h
+	}
+
+	if hc, ok := p.i.(HealthChecker); ok {
+		if err := hc.Health(ctx); err != nil {
+			return integrationHealth{State: stateFailed, LastError: err.Error()}
+		}
+	}
+	return integrationHealth{State: stateRunning}
+}
+
+// backoffAndWait records an abnormal exit, then either waits out an
+// exponential backoff (with full jitter) before the caller restarts the
+// integration, or - once the circuit breaker trips - waits out a cool-down
+// before resetting the failure streak. It returns true if the caller should
+// give up and stop restarting altogether.
+func (p *integrationProcess) backoffAndWait(runStart time.Time, err error) (giveUp bool) {
+	integrationAbnormalExits.WithLabelValues(p.cfg.Name()).Inc()
+
+	p.stateMut.Lock()
+	if p.backoff.resetWindow > 0 && time.Since(runStart) >= p.backoff.resetWindow {
+		p.consecutiveFailures = 0
+	}
+	p.consecutiveFailures++
+	failures := p.consecutiveFailures
+	p.stateMut.Unlock()
+
+	integrationConsecutiveFailures.WithLabelValues(p.cfg.Name()).Set(float64(failures))
+
+	if p.backoff.maxConsecutiveFailures > 0 && failures >= p.backoff.maxConsecutiveFailures {
+		p.setState(stateFailed, fmt.Errorf("circuit breaker open after %d consecutive failures: %w", failures, err))
+
+		if p.backoff.circuitBreakerCooldown <= 0 {
+			level.Error(p.log).Log("msg", "integration tripped its circuit breaker, it will not be restarted until its config is re-applied", "integration", p.cfg.Name(), "consecutive_failures", failures)
+			return true
+		}
+
+		level.Error(p.log).Log("msg", "integration tripped its circuit breaker, waiting for cooldown before retrying", "integration", p.cfg.Name(), "consecutive_failures", failures, "cooldown", p.backoff.circuitBreakerCooldown)
+		integrationRestartBackoffSeconds.WithLabelValues(p.cfg.Name()).Set(p.backoff.circuitBreakerCooldown.Seconds())
+
+		select {
+		case <-time.After(p.backoff.circuitBreakerCooldown):
+		case <-p.ctx.Done():
+			return true
+		}
+
+		p.stateMut.Lock()
+		p.consecutiveFailures = 0
+		p.stateMut.Unlock()
+		return false
+	}
+
+	backoff := fullJitterBackoff(p.backoff.base, p.backoff.max, failures)
+	p.setState(stateBackingOff, err)
+	integrationRestartBackoffSeconds.WithLabelValues(p.cfg.Name()).Set(backoff.Seconds())
+	level.Error(p.log).Log("msg", "integration stopped abnormally, restarting after backoff", "err", err, "integration", p.cfg.Name(), "backoff", backoff, "consecutive_failures", failures)
+
+	select {
+	case <-time.After(backoff):
+	case <-p.ctx.Done():
+		return true
+	}
+	return false
+}
+
+// absoluteMaxBackoff bounds the doubling in fullJitterBackoff even when max is
+// non-positive ("no cap"). Without it, an integration that keeps failing
+// without ever hitting its reset window would double capped past the range
+// of time.Duration (an int64 count of nanoseconds) after ~31 failures,
+// wrapping it negative and panicking the later call to rand.Int63n.
+const absoluteMaxBackoff = 1 * time.Hour
+
+// fullJitterBackoff returns a random duration in [0, min(max, base*2^(attempt-1))],
+// implementing "full jitter" exponential backoff. attempt is 1-indexed. A
+// non-positive max disables the configured cap, but capped is still held to
+// absoluteMaxBackoff so it can never overflow time.Duration.
+func fullJitterBackoff(base, max time.Duration, attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+
+	capped := base
+	for i := 1; i < attempt; i++ {
+		if capped >= absoluteMaxBackoff || (max > 0 && capped >= max) {
+			break
+		}
+		capped *= 2
+	}
+	if capped > absoluteMaxBackoff {
+		capped = absoluteMaxBackoff
+	}
+	if max > 0 && capped > max {
+		capped = max
+	}
+	if capped <= 0 {
+		return 0
+	}
+
+	return time.Duration(rand.Int63n(int64(capped) + 1))
 }
 
 func (m *Manager) instanceConfigForIntegration(icfg Config, i Integration, cfg ManagerConfig) instance.Config {
 	common := icfg.CommonConfig()
+
+	// Errors computing the instance ID were already surfaced during
+	// validation in ApplyConfig, so fall back to the default instance ID on
+	// failure here rather than propagating an error through this signature.
+	instanceID, err := instanceIDForConfig(icfg)
+	if err != nil {
+		instanceID = "default"
+	}
+
+	instanceCfg := instance.DefaultConfig
+	instanceCfg.Name = integrationKey(icfg.Name(), instanceID)
+	instanceCfg.ScrapeConfigs = m.scrapeConfigsForIntegration(icfg, i, cfg, false)
+	instanceCfg.RemoteWrite = cfg.PrometheusRemoteWrite
+	if common.WALTruncateFrequency > 0 {
+		instanceCfg.WALTruncateFrequency = common.WALTruncateFrequency
+	}
+	return instanceCfg
+}
+
+// scrapeConfigsForIntegration builds the set of Prometheus scrape configs
+// used to collect metrics from an integration, shared by both the
+// instance.Manager path (instanceConfigForIntegration) and the autoscrape
+// path (ApplyConfig). autoscraped must be true when the integration is
+// Autoscrapable: its scrape targets come from Autoscrapable.Targets()
+// instead, so computing ServiceDiscoveryConfigs (and any relabeling to
+// support it) would be wasted work that's silently discarded by
+// autoscrape.Scraper.
+func (m *Manager) scrapeConfigsForIntegration(icfg Config, i Integration, cfg ManagerConfig, autoscraped bool) []*config.ScrapeConfig {
+	common := icfg.CommonConfig()
 	relabelConfigs := append(cfg.DefaultRelabelConfigs(m.hostname), common.RelabelConfigs...)
 
 	schema := "http"
@@ -365,18 +796,40 @@ func (m *Manager) instanceConfigForIntegration(icfg Config, i Integration, cfg M
 		httpClientConfig.TLSConfig = cfg.TLSConfig
 	}
 
+	// instanceID distinguishes this integration instance's job name and
+	// metrics path from any other instance of the same integration type.
+	// Without it, two instances of the same integration would generate
+	// identical job names - harmless when each instance lives in its own
+	// Prometheus instance.Manager, but fatal for the shared autoscrape.Scraper,
+	// which keys both its discovery configs and scrape configs by job name.
+	instanceID, err := instanceIDForConfig(icfg)
+	if err != nil {
+		instanceID = defaultInstanceID
+	}
+
+	// Computed once per integration rather than per scrape config: if the
+	// integration implements ServiceDiscoverer, this may call out to Consul,
+	// Kubernetes, etc., and shouldn't be repeated for every ScrapeConfigs()
+	// entry.
+	var serviceDiscoveryConfigs discovery.Configs
+	if !autoscraped {
+		var sdRelabelConfigs []*relabel.Config
+		serviceDiscoveryConfigs, sdRelabelConfigs = m.serviceDiscoveryConfigsForIntegration(i, cfg)
+		relabelConfigs = append(relabelConfigs, sdRelabelConfigs...)
+	}
+
 	var scrapeConfigs []*config.ScrapeConfig
 
 	for _, isc := range i.ScrapeConfigs() {
 		sc := &config.ScrapeConfig{
-			JobName:                 fmt.Sprintf("integrations/%s", isc.JobName),
-			MetricsPath:             path.Join("/integrations", icfg.Name(), isc.MetricsPath),
+			JobName:                 fmt.Sprintf("integrations/%s/%s", isc.JobName, instanceID),
+			MetricsPath:             path.Join("/integrations", icfg.Name(), instanceID, isc.MetricsPath),
 			Scheme:                  schema,
 			HonorLabels:             false,
 			HonorTimestamps:         true,
 			ScrapeInterval:          model.Duration(common.ScrapeInterval),
 			ScrapeTimeout:           model.Duration(common.ScrapeTimeout),
-			ServiceDiscoveryConfigs: m.scrapeServiceDiscovery(cfg),
+			ServiceDiscoveryConfigs: serviceDiscoveryConfigs,
 			RelabelConfigs:          relabelConfigs,
 			MetricRelabelConfigs:    common.MetricRelabelConfigs,
 			HTTPClientConfig:        httpClientConfig,
@@ -385,29 +838,107 @@ func (m *Manager) instanceConfigForIntegration(icfg Config, i Integration, cfg M
 		scrapeConfigs = append(scrapeConfigs, sc)
 	}
 
-	instanceCfg := instance.DefaultConfig
-	instanceCfg.Name = integrationKey(icfg.Name())
-	instanceCfg.ScrapeConfigs = scrapeConfigs
-	instanceCfg.RemoteWrite = cfg.PrometheusRemoteWrite
-	if common.WALTruncateFrequency > 0 {
-		instanceCfg.WALTruncateFrequency = common.WALTruncateFrequency
+	return scrapeConfigs
+}
+
+// autoscrapeTargetsToDiscoveryConfigs converts a set of autoscrape.Targets
+// into the static discovery.Configs expected by the autoscrape.Scraper.
+func autoscrapeTargetsToDiscoveryConfigs(targets []autoscrape.Target) discovery.Configs {
+	group := &targetgroup.Group{}
+	for _, t := range targets {
+		labels := model.LabelSet{}
+		for k, v := range t.Labels {
+			labels[model.LabelName(k)] = model.LabelValue(v)
+		}
+		group.Targets = append(group.Targets, labels)
 	}
-	return instanceCfg
+	return discovery.Configs{discovery.StaticConfig{group}}
 }
 
 // integrationKey returns the key for an integration Config, used for its
-// instance name and name in the process cache.
-func integrationKey(name string) string {
-	return fmt.Sprintf("integration/%s", name)
+// instance name and name in the process cache. instance distinguishes
+// multiple running instances of the same integration type.
+func integrationKey(name, instance string) string {
+	return fmt.Sprintf("integration/%s/%s", name, instance)
 }
 
-func (m *Manager) scrapeServiceDiscovery(cfg ManagerConfig) discovery.Configs {
+// defaultInstanceID is the instance ID used for an integration that doesn't
+// set CommonConfig().Instance explicitly. The vast majority of configs run a
+// single instance of a given integration type, and this needs to be stable
+// across reloads: deriving it from the config's contents would rotate the
+// generated job name (and therefore the integration's metric history) on any
+// unrelated config edit, such as tweaking scrape_interval or
+// relabel_configs. Configuring more than one unnamed instance of the same
+// type collides on this value and is rejected by ApplyConfig's duplicate-key
+// check, so instance must be set explicitly whenever more than one instance
+// of a type is configured.
+const defaultInstanceID = "default"
+
+// instanceIDForConfig returns the instance identifier to use for ic.
+func instanceIDForConfig(ic Config) (string, error) {
+	if instance := ic.CommonConfig().Instance; instance != "" {
+		return instance, nil
+	}
+	return defaultInstanceID, nil
+}
+
+// serviceDiscoveryConfigsForIntegration returns the discovery.Configs used to
+// find scrape targets for an integration, along with any relabel configs
+// needed to actually scrape them. Integrations that implement
+// ServiceDiscoverer have their own discovery.Configs merged alongside the
+// agent-local target, so an integration that internally proxies many
+// endpoints (a blackbox-style prober, a multi-target snmp exporter) can
+// produce one scrape target per discovered instance. Since those discovered
+// targets live at their own address rather than the agent's, the returned
+// relabel configs move each one's __address__ into __param_target and
+// replace __address__ with the agent's own listen address, the same
+// "blackbox" pattern blackbox_exporter and snmp_exporter use: Prometheus
+// ends up scraping the agent, passing along which target to probe, and the
+// integration's MetricsHandler is responsible for reading the target query
+// parameter. Integrations that don't implement ServiceDiscoverer, or that
+// return nil, fall back to today's behavior of scraping only the agent's own
+// listen address, with no extra relabeling required.
+func (m *Manager) serviceDiscoveryConfigsForIntegration(i Integration, cfg ManagerConfig) (discovery.Configs, []*relabel.Config) {
+	local := m.scrapeServiceDiscovery(cfg)
+
+	sd, ok := i.(ServiceDiscoverer)
+	if !ok {
+		return local, nil
+	}
+
+	extra := sd.DiscoveryConfigs()
+	if extra == nil {
+		return local, nil
+	}
+
+	proxyRelabelConfigs := []*relabel.Config{{
+		Action:       relabel.Replace,
+		SourceLabels: model.LabelNames{model.AddressLabel},
+		Regex:        relabel.MustNewRegexp("(.*)"),
+		Replacement:  "$1",
+		TargetLabel:  model.LabelName(model.ParamLabelPrefix + "target"),
+	}, {
+		Action:      relabel.Replace,
+		Replacement: m.localAddr(cfg),
+		TargetLabel: model.AddressLabel,
+	}}
+
+	return append(local, extra...), proxyRelabelConfigs
+}
+
+// localAddr returns the address the Agent itself is listening on, used both
+// as the default scrape target and as the proxy target for discovered
+// ServiceDiscoverer targets.
+func (m *Manager) localAddr(cfg ManagerConfig) string {
 	// A blank host somehow works, but it then requires a sever name to be set under tls.
 	newHost := cfg.ListenHost
 	if newHost == "" {
 		newHost = "127.0.0.1"
 	}
-	localAddr := fmt.Sprintf("%s:%d", newHost, cfg.ListenPort)
+	return fmt.Sprintf("%s:%d", newHost, cfg.ListenPort)
+}
+
+func (m *Manager) scrapeServiceDiscovery(cfg ManagerConfig) discovery.Configs {
 	labels := model.LabelSet{}
 	if cfg.UseHostnameLabel {
 		labels[model.LabelName("agent_hostname")] = model.LabelValue(m.hostname)
@@ -418,7 +949,7 @@ func (m *Manager) scrapeServiceDiscovery(cfg ManagerConfig) discovery.Configs {
 
 	return discovery.Configs{
 		discovery.StaticConfig{{
-			Targets: []model.LabelSet{{model.AddressLabel: model.LabelValue(localAddr)}},
+			Targets: []model.LabelSet{{model.AddressLabel: model.LabelValue(m.localAddr(cfg))}},
 			Labels:  labels,
 		}},
 	}
@@ -468,14 +999,51 @@ func (m *Manager) WireAPI(r *mux.Router) {
 		return cacheEntry.handler
 	}
 
-	r.HandleFunc("/integrations/{name}/metrics", func(rw http.ResponseWriter, r *http.Request) {
+	r.HandleFunc("/integrations/{name}/{instance}/metrics", func(rw http.ResponseWriter, r *http.Request) {
 		m.integrationsMut.RLock()
 		defer m.integrationsMut.RUnlock()
 
-		key := integrationKey(mux.Vars(r)["name"])
+		vars := mux.Vars(r)
+		key := integrationKey(vars["name"], vars["instance"])
 		handler := loadHandler(key)
 		handler.ServeHTTP(rw, r)
 	})
+
+	r.HandleFunc("/integrations/{name}/{instance}/health", func(rw http.ResponseWriter, r *http.Request) {
+		m.integrationsMut.RLock()
+		defer m.integrationsMut.RUnlock()
+
+		vars := mux.Vars(r)
+		key := integrationKey(vars["name"], vars["instance"])
+
+		p, ok := m.integrations[key]
+		if !ok {
+			http.NotFound(rw, r)
+			return
+		}
+
+		m.writeHealthJSON(rw, p.Health(r.Context()))
+	})
+
+	r.HandleFunc("/integrations-health", func(rw http.ResponseWriter, r *http.Request) {
+		m.integrationsMut.RLock()
+		defer m.integrationsMut.RUnlock()
+
+		result := make(map[string]integrationHealth, len(m.integrations))
+		for key, p := range m.integrations {
+			result[key] = p.Health(r.Context())
+		}
+
+		m.writeHealthJSON(rw, result)
+	})
+}
+
+// writeHealthJSON writes v as the JSON body of a health response.
+func (m *Manager) writeHealthJSON(rw http.ResponseWriter, v interface{}) {
+	rw.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(rw).Encode(v); err != nil {
+		level.Error(m.logger).Log("msg", "failed to encode integrations health response", "err", err)
+	}
 }
 
 func internalServiceError(w http.ResponseWriter, r *http.Request) {
@@ -487,4 +1055,53 @@ func internalServiceError(w http.ResponseWriter, r *http.Request) {
 func (m *Manager) Stop() {
 	m.cancel()
 	m.wg.Wait()
+	m.autoscraper.Stop()
+}
+
+// StopWithTimeout stops the manager and all of its integrations, waiting at
+// most until ctx's deadline for them to exit. Unlike Stop, a hung integration
+// (a stuck HTTP scrape, a blocking exporter Run) cannot pin shutdown forever.
+// It returns an error naming the integrations that failed to exit in time;
+// the Manager is stopped either way.
+func (m *Manager) StopWithTimeout(ctx context.Context) error {
+	m.cancel()
+
+	m.integrationsMut.RLock()
+	processes := make(map[string]*integrationProcess, len(m.integrations))
+	for key, p := range m.integrations {
+		processes[key] = p
+	}
+	m.integrationsMut.RUnlock()
+
+	var (
+		wg       sync.WaitGroup
+		mut      sync.Mutex
+		timedOut []string
+	)
+
+	for key, p := range processes {
+		wg.Add(1)
+		go func(key string, p *integrationProcess) {
+			defer wg.Done()
+
+			start := time.Now()
+			select {
+			case <-p.exited:
+				level.Info(m.logger).Log("msg", "integration exited", "integration", p.cfg.Name(), "duration", time.Since(start))
+			case <-ctx.Done():
+				level.Error(m.logger).Log("msg", "integration did not exit before the shutdown deadline", "integration", p.cfg.Name())
+				mut.Lock()
+				timedOut = append(timedOut, key)
+				mut.Unlock()
+			}
+		}(key, p)
+	}
+	wg.Wait()
+
+	m.autoscraper.Stop()
+
+	if len(timedOut) > 0 {
+		return fmt.Errorf("integrations did not exit before the shutdown deadline: %s", strings.Join(timedOut, ", "))
+	}
+	return nil
 }
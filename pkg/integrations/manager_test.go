@@ -0,0 +1,93 @@
+package integrations
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/grafana/agent/pkg/integrations/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFullJitterBackoff_NoCapDoesNotOverflow(t *testing.T) {
+	// A disabled cap (max <= 0) must not let capped double past the point
+	// where it overflows time.Duration, even across many failures.
+	for attempt := 1; attempt <= 100; attempt++ {
+		backoff := fullJitterBackoff(time.Second, 0, attempt)
+		require.GreaterOrEqual(t, backoff, time.Duration(0))
+		require.LessOrEqual(t, backoff, absoluteMaxBackoff)
+	}
+}
+
+func TestFullJitterBackoff_RespectsConfiguredMax(t *testing.T) {
+	max := 30 * time.Second
+	for attempt := 1; attempt <= 20; attempt++ {
+		backoff := fullJitterBackoff(time.Second, max, attempt)
+		require.LessOrEqual(t, backoff, max)
+	}
+}
+
+func TestFullJitterBackoff_GrowsWithAttempt(t *testing.T) {
+	// The upper bound of the jitter range should increase (until capped), so
+	// later attempts should tend to produce larger backoffs. We can't assert
+	// this of any single sample since it's randomized, but the max of many
+	// samples at a later attempt should exceed the max of many samples at an
+	// early attempt.
+	sample := func(attempt int) time.Duration {
+		var max time.Duration
+		for i := 0; i < 1000; i++ {
+			if b := fullJitterBackoff(time.Millisecond, 0, attempt); b > max {
+				max = b
+			}
+		}
+		return max
+	}
+
+	require.Greater(t, sample(10), sample(1))
+}
+
+// fakeConfig is a minimal Config implementation used to test instance ID and
+// key derivation without depending on a concrete integration type.
+type fakeConfig struct {
+	InstanceName string `yaml:"instance,omitempty"`
+	Value        string `yaml:"value,omitempty"`
+}
+
+func (c fakeConfig) Name() string { return "fake" }
+
+func (c fakeConfig) CommonConfig() common.Config {
+	return common.Config{Instance: c.InstanceName}
+}
+
+func (c fakeConfig) NewIntegration(l log.Logger) (Integration, error) {
+	return nil, nil
+}
+
+var _ Config = fakeConfig{}
+
+func TestInstanceIDForConfig_UsesExplicitInstance(t *testing.T) {
+	id, err := instanceIDForConfig(fakeConfig{InstanceName: "primary", Value: "a"})
+	require.NoError(t, err)
+	require.Equal(t, "primary", id)
+}
+
+func TestInstanceIDForConfig_StableWhenUnset(t *testing.T) {
+	// Configs that don't set an explicit instance must always get the same
+	// ID, regardless of any other field, so that editing unrelated settings
+	// (scrape_interval, relabel_configs, ...) doesn't rotate the generated
+	// job name and fragment the integration's metric history.
+	idA, err := instanceIDForConfig(fakeConfig{Value: "a"})
+	require.NoError(t, err)
+
+	idB, err := instanceIDForConfig(fakeConfig{Value: "b"})
+	require.NoError(t, err)
+
+	require.Equal(t, idA, idB)
+	require.Equal(t, defaultInstanceID, idA)
+}
+
+func TestIntegrationKey_DistinguishesInstances(t *testing.T) {
+	keyA := integrationKey("fake", "a")
+	keyB := integrationKey("fake", "b")
+	require.NotEqual(t, keyA, keyB)
+}